@@ -0,0 +1,108 @@
+package twitterfeed
+
+import (
+	"context"
+	"time"
+)
+
+// Twitter's published backoff guidelines for the streaming API:
+// https://developer.twitter.com/en/docs/twitter-api/v1/tweets/filter-realtime/guides/connecting
+const (
+	netErrInitial = 250 * time.Millisecond
+	netErrMax     = 16 * time.Second
+	netErrStep    = 250 * time.Millisecond
+
+	httpErrInitial = 5 * time.Second
+	httpErrMax     = 320 * time.Second
+
+	rateLimitInitial = 1 * time.Minute
+	rateLimitMax     = 15 * time.Minute
+)
+
+// backoff implements Twitter's recommended reconnection strategy for the
+// streaming API: a linear backoff on TCP/network errors, and separate
+// exponential backoffs for rate limiting (420/429) and other server errors.
+// A successful connection that yields at least one message resets all of
+// the counters.
+type backoff struct {
+	netErrWait  time.Duration
+	httpErrWait time.Duration
+	rateWait    time.Duration
+
+	// pending is the wait duration for the error category most recently
+	// recorded by OnNetErr/OnHTTPErr. Wait uses this rather than the max
+	// across all three categories, so an isolated rate limit doesn't
+	// inflate the wait for an unrelated net/5xx error that follows it.
+	pending time.Duration
+}
+
+// OnNetErr records a TCP/network error and advances the linear backoff.
+func (b *backoff) OnNetErr() {
+	if b.netErrWait == 0 {
+		b.netErrWait = netErrInitial
+	} else {
+		b.netErrWait += netErrStep
+		if b.netErrWait > netErrMax {
+			b.netErrWait = netErrMax
+		}
+	}
+	b.pending = b.netErrWait
+}
+
+// OnHTTPErr records an HTTP error response and advances the appropriate
+// exponential backoff for the given status code.
+func (b *backoff) OnHTTPErr(status int) {
+	switch status {
+	case http420, http429:
+		if b.rateWait == 0 {
+			b.rateWait = rateLimitInitial
+		} else {
+			b.rateWait *= 2
+			if b.rateWait > rateLimitMax {
+				b.rateWait = rateLimitMax
+			}
+		}
+		b.pending = b.rateWait
+	default:
+		if b.httpErrWait == 0 {
+			b.httpErrWait = httpErrInitial
+		} else {
+			b.httpErrWait *= 2
+			if b.httpErrWait > httpErrMax {
+				b.httpErrWait = httpErrMax
+			}
+		}
+		b.pending = b.httpErrWait
+	}
+}
+
+// OnSuccess resets all backoff counters after a successful connection that
+// produced at least one message.
+func (b *backoff) OnSuccess() {
+	b.netErrWait = 0
+	b.httpErrWait = 0
+	b.rateWait = 0
+	b.pending = 0
+}
+
+// Wait blocks for the duration recorded by the most recent OnNetErr or
+// OnHTTPErr call, or until ctx is done. It returns ctx.Err() if the
+// context is cancelled first.
+func (b *backoff) Wait(ctx context.Context) error {
+	if b.pending == 0 {
+		return nil
+	}
+	t := time.NewTimer(b.pending)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+const (
+	http420 = 420
+	http429 = 429
+)
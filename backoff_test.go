@@ -0,0 +1,96 @@
+package twitterfeed
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBackoffOnNetErr(t *testing.T) {
+	var b backoff
+	b.OnNetErr()
+	if b.netErrWait != netErrInitial {
+		t.Fatalf("netErrWait = %v, want %v", b.netErrWait, netErrInitial)
+	}
+	b.OnNetErr()
+	if want := netErrInitial + netErrStep; b.netErrWait != want {
+		t.Fatalf("netErrWait = %v, want %v", b.netErrWait, want)
+	}
+	for i := 0; i < 100; i++ {
+		b.OnNetErr()
+	}
+	if b.netErrWait != netErrMax {
+		t.Fatalf("netErrWait = %v, want capped at %v", b.netErrWait, netErrMax)
+	}
+}
+
+func TestBackoffOnHTTPErr(t *testing.T) {
+	var b backoff
+	b.OnHTTPErr(420)
+	if b.rateWait != rateLimitInitial {
+		t.Fatalf("rateWait = %v, want %v", b.rateWait, rateLimitInitial)
+	}
+	b.OnHTTPErr(429)
+	if want := rateLimitInitial * 2; b.rateWait != want {
+		t.Fatalf("rateWait = %v, want %v", b.rateWait, want)
+	}
+	for i := 0; i < 100; i++ {
+		b.OnHTTPErr(420)
+	}
+	if b.rateWait != rateLimitMax {
+		t.Fatalf("rateWait = %v, want capped at %v", b.rateWait, rateLimitMax)
+	}
+
+	b.OnHTTPErr(503)
+	if b.httpErrWait != httpErrInitial {
+		t.Fatalf("httpErrWait = %v, want %v", b.httpErrWait, httpErrInitial)
+	}
+	for i := 0; i < 100; i++ {
+		b.OnHTTPErr(503)
+	}
+	if b.httpErrWait != httpErrMax {
+		t.Fatalf("httpErrWait = %v, want capped at %v", b.httpErrWait, httpErrMax)
+	}
+}
+
+func TestBackoffOnSuccess(t *testing.T) {
+	b := backoff{netErrWait: netErrMax, httpErrWait: httpErrMax, rateWait: rateLimitInitial}
+	b.OnSuccess()
+	if b.netErrWait != 0 || b.httpErrWait != 0 || b.rateWait != 0 {
+		t.Fatalf("OnSuccess did not reset all counters: %+v", b)
+	}
+}
+
+func TestBackoffWaitZero(t *testing.T) {
+	var b backoff
+	start := time.Now()
+	if err := b.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	if d := time.Since(start); d > 50*time.Millisecond {
+		t.Fatalf("Wait with no pending backoff took %v, want ~instant", d)
+	}
+}
+
+func TestBackoffWaitUsesMostRecentCategory(t *testing.T) {
+	// A large stale rateWait from an earlier rate limit must not inflate
+	// Wait for an unrelated net error recorded afterwards.
+	b := backoff{rateWait: time.Hour}
+	b.OnNetErr()
+	start := time.Now()
+	if err := b.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	if d := time.Since(start); d > 2*netErrInitial {
+		t.Fatalf("Wait took %v, want ~netErrInitial (%v), not the stale rateWait", d, netErrInitial)
+	}
+}
+
+func TestBackoffWaitContextCancelled(t *testing.T) {
+	b := backoff{pending: time.Hour}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := b.Wait(ctx); err != context.Canceled {
+		t.Fatalf("Wait returned %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,114 @@
+package twitterfeed
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// defaultBearerTTL is used when no WithBearerTTL option is given to
+// NewTweetReaderOAuth2App.
+const defaultBearerTTL = 15 * time.Minute
+
+// oauth2TokenURL is where refreshBearer fetches app-only bearer tokens.
+// It's a var, rather than a const alongside the v2 endpoint URLs, so
+// tests can point it at a local server.
+var oauth2TokenURL = "https://api.twitter.com/oauth2/token"
+
+// WithBearerTTL overrides how long an app-only bearer token fetched by
+// NewTweetReaderOAuth2App is cached before being refreshed.
+func WithBearerTTL(ttl time.Duration) Option {
+	return func(r *TweetReader) {
+		r.bearerTTL = ttl
+	}
+}
+
+// NewTweetReaderBearer creates a TweetReader authenticated with an
+// already-obtained app-only bearer token. It uses Twitter's v2 filtered
+// stream endpoints rather than the v1.1 OAuth1 ones.
+func NewTweetReaderBearer(bearerToken string, opts ...Option) *TweetReader {
+	r := newTweetReader(opts...)
+	r.bearerToken = bearerToken
+	r.v2 = true
+	r.useV2DecoderByDefault()
+	return r
+}
+
+// NewTweetReaderOAuth2App creates a TweetReader that lazily obtains an
+// app-only bearer token from clientID/clientSecret via POST oauth2/token,
+// refreshing it as it expires. It uses Twitter's v2 filtered stream
+// endpoints rather than the v1.1 OAuth1 ones.
+func NewTweetReaderOAuth2App(clientID, clientSecret string, opts ...Option) *TweetReader {
+	r := newTweetReader(opts...)
+	r.clientID = clientID
+	r.clientSecret = clientSecret
+	if r.bearerTTL == 0 {
+		r.bearerTTL = defaultBearerTTL
+	}
+	r.v2 = true
+	r.useV2DecoderByDefault()
+	return r
+}
+
+// useV2DecoderByDefault switches r to the v2-aware decoder, unless the
+// caller already overrode it via WithDecoder.
+func (r *TweetReader) useV2DecoderByDefault() {
+	if _, ok := r.decoder.(jsonDecoder); ok {
+		r.decoder = v2JSONDecoder{}
+	}
+}
+
+// bearer returns a currently-valid bearer token, refreshing it first
+// if it has expired.
+func (r *TweetReader) bearer(ctx context.Context) (string, error) {
+	r.bearerMu.RLock()
+	tok := r.bearerToken
+	stale := r.clientID != "" && (tok == "" || !time.Now().Before(r.bearerExpiry))
+	r.bearerMu.RUnlock()
+	if !stale {
+		return tok, nil
+	}
+	return r.refreshBearer(ctx)
+}
+
+// refreshBearer unconditionally fetches a new app-only bearer token via
+// POST oauth2/token. It is a no-op, returning the existing token, for a
+// TweetReader built with NewTweetReaderBearer.
+func (r *TweetReader) refreshBearer(ctx context.Context) (string, error) {
+	if r.clientID == "" {
+		r.bearerMu.RLock()
+		defer r.bearerMu.RUnlock()
+		return r.bearerToken, nil
+	}
+	r.bearerMu.Lock()
+	defer r.bearerMu.Unlock()
+	form := url.Values{"grant_type": {"client_credentials"}}
+	req, err := http.NewRequest("POST", oauth2TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req = req.WithContext(ctx)
+	req.SetBasicAuth(url.QueryEscape(r.clientID), url.QueryEscape(r.clientSecret))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded;charset=UTF-8")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("twitterfeed: oauth2/token returned status %d", resp.StatusCode)
+	}
+	var result struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	r.bearerToken = result.AccessToken
+	r.bearerExpiry = time.Now().Add(r.bearerTTL)
+	return r.bearerToken, nil
+}
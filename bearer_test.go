@@ -0,0 +1,84 @@
+package twitterfeed
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestBearerStaticTokenNeverRefreshes(t *testing.T) {
+	r := NewTweetReaderBearer("static-token")
+	tok, err := r.bearer(context.Background())
+	if err != nil {
+		t.Fatalf("bearer: %v", err)
+	}
+	if tok != "static-token" {
+		t.Fatalf("bearer = %q, want %q", tok, "static-token")
+	}
+}
+
+func TestBearerCachesUntilExpiry(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		json.NewEncoder(w).Encode(struct {
+			AccessToken string `json:"access_token"`
+		}{"fresh-token"})
+	}))
+	defer srv.Close()
+	orig := oauth2TokenURL
+	oauth2TokenURL = srv.URL
+	defer func() { oauth2TokenURL = orig }()
+
+	r := NewTweetReaderOAuth2App("id", "secret", WithBearerTTL(time.Hour))
+	tok, err := r.bearer(context.Background())
+	if err != nil {
+		t.Fatalf("bearer: %v", err)
+	}
+	if tok != "fresh-token" {
+		t.Fatalf("bearer = %q, want %q", tok, "fresh-token")
+	}
+	if requests != 1 {
+		t.Fatalf("oauth2/token requested %d times, want 1", requests)
+	}
+
+	// still within the TTL: no second request.
+	if _, err := r.bearer(context.Background()); err != nil {
+		t.Fatalf("bearer: %v", err)
+	}
+	if requests != 1 {
+		t.Fatalf("oauth2/token requested %d times, want 1 (cached)", requests)
+	}
+}
+
+func TestBearerRefreshesAfterExpiry(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		json.NewEncoder(w).Encode(struct {
+			AccessToken string `json:"access_token"`
+		}{"fresh-token"})
+	}))
+	defer srv.Close()
+	orig := oauth2TokenURL
+	oauth2TokenURL = srv.URL
+	defer func() { oauth2TokenURL = orig }()
+
+	r := NewTweetReaderOAuth2App("id", "secret", WithBearerTTL(time.Hour))
+	r.bearerToken = "stale-token"
+	r.bearerExpiry = time.Now().Add(-time.Minute)
+
+	tok, err := r.bearer(context.Background())
+	if err != nil {
+		t.Fatalf("bearer: %v", err)
+	}
+	if tok != "fresh-token" {
+		t.Fatalf("bearer = %q, want %q", tok, "fresh-token")
+	}
+	if requests != 1 {
+		t.Fatalf("oauth2/token requested %d times, want 1", requests)
+	}
+}
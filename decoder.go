@@ -0,0 +1,31 @@
+package twitterfeed
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Decoder decodes a single tweet payload from the filter stream. Users can
+// supply their own Decoder to NewTweetReader (via WithDecoder) to plug in a
+// different tweet schema.
+type Decoder interface {
+	Decode(r io.Reader) (Tweet, error)
+}
+
+// jsonDecoder is the default Decoder, decoding the standard Twitter filter
+// stream tweet payload into a Tweet.
+type jsonDecoder struct{}
+
+// Decode implements Decoder.
+func (jsonDecoder) Decode(r io.Reader) (Tweet, error) {
+	var t Tweet
+	err := json.NewDecoder(r).Decode(&t)
+	return t, err
+}
+
+// WithDecoder overrides the default tweet Decoder.
+func WithDecoder(d Decoder) Option {
+	return func(r *TweetReader) {
+		r.decoder = d
+	}
+}
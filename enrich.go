@@ -0,0 +1,206 @@
+package twitterfeed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Enricher enriches a Tweet in place, for example by classifying its text
+// or running sentiment analysis, before it is sent to the channel returned
+// by Run/RunWithOptions.
+type Enricher interface {
+	Enrich(ctx context.Context, t *Tweet) error
+}
+
+// Observer receives per-enricher latency and error metrics. If no Observer
+// is set via WithObserver, enrichment metrics are published via expvar
+// instead.
+type Observer interface {
+	ObserveEnrich(enricher string, d time.Duration, err error)
+}
+
+// enrichDropped counts tweets dropped across all TweetReaders because an
+// enrichment pool's input buffer was full.
+var enrichDropped = expvar.NewInt("twitterfeed_enrich_dropped")
+
+// enrichMetrics holds the expvar fallback per-enricher latency/error
+// counters used when a TweetReader has no Observer configured, keyed by
+// "<enricher>_calls", "<enricher>_errors" and "<enricher>_latency_ns".
+var enrichMetrics = expvar.NewMap("twitterfeed_enrich_metrics")
+
+// observeEnrichExpvar is the Observer fallback: it records calls, errors
+// and total latency for enricher under enrichMetrics.
+func observeEnrichExpvar(enricher string, d time.Duration, err error) {
+	enrichMetrics.Add(enricher+"_calls", 1)
+	enrichMetrics.Add(enricher+"_latency_ns", int64(d))
+	if err != nil {
+		enrichMetrics.Add(enricher+"_errors", 1)
+	}
+}
+
+const (
+	defaultEnrichConcurrency = 4
+	defaultEnrichBuffer      = 64
+)
+
+// Use sets the enrichers run on every tweet between decoding and sending
+// it to the channel returned by Run/RunWithOptions/RunPolling. Call it
+// before Run.
+func (r *TweetReader) Use(enrichers ...Enricher) {
+	r.enrichers = enrichers
+}
+
+// WithEnrichConcurrency overrides how many tweets are enriched
+// concurrently. Defaults to 4.
+func WithEnrichConcurrency(n int) Option {
+	return func(r *TweetReader) {
+		r.enrichConcurrency = n
+	}
+}
+
+// WithEnrichBuffer overrides the size of the enrichment pool's input
+// buffer. Once full, new tweets are dropped (incrementing EnrichDropped)
+// rather than stalling the decoder. Defaults to 64.
+func WithEnrichBuffer(n int) Option {
+	return func(r *TweetReader) {
+		r.enrichBuffer = n
+	}
+}
+
+// WithObserver sets the Observer notified of each enricher's latency and
+// error for every tweet.
+func WithObserver(o Observer) Option {
+	return func(r *TweetReader) {
+		r.observer = o
+	}
+}
+
+// EnrichDropped returns the number of tweets this TweetReader has dropped
+// because the enrichment pool's input buffer was full.
+func (r *TweetReader) EnrichDropped() int64 {
+	return atomic.LoadInt64(&r.enrichDropped)
+}
+
+// finalize wraps raw with the enrichment pool if any enrichers are
+// configured, otherwise returns raw unchanged.
+func (r *TweetReader) finalize(ctx context.Context, raw <-chan Tweet) <-chan Tweet {
+	if len(r.enrichers) == 0 {
+		return raw
+	}
+	return r.enrich(ctx, raw)
+}
+
+// enrich runs a bounded pool of workers that pass each tweet from in
+// through every configured Enricher before sending it to the returned
+// channel. If the pool falls behind, tweets are dropped rather than
+// blocking the decoder.
+func (r *TweetReader) enrich(ctx context.Context, in <-chan Tweet) <-chan Tweet {
+	concurrency := r.enrichConcurrency
+	if concurrency <= 0 {
+		concurrency = defaultEnrichConcurrency
+	}
+	buffer := r.enrichBuffer
+	if buffer <= 0 {
+		buffer = defaultEnrichBuffer
+	}
+	work := make(chan Tweet, buffer)
+	out := make(chan Tweet)
+
+	go func() {
+		defer close(work)
+		for t := range in {
+			select {
+			case work <- t:
+			default:
+				atomic.AddInt64(&r.enrichDropped, 1)
+				enrichDropped.Add(1)
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	wg.Add(concurrency)
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			defer wg.Done()
+			for t := range work {
+				for _, e := range r.enrichers {
+					start := time.Now()
+					err := e.Enrich(ctx, &t)
+					name := fmt.Sprintf("%T", e)
+					if r.observer != nil {
+						r.observer.ObserveEnrich(name, time.Since(start), err)
+					} else {
+						observeEnrichExpvar(name, time.Since(start), err)
+					}
+				}
+				select {
+				case out <- t:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+
+	return out
+}
+
+// HTTPEnricher is an Enricher that POSTs a tweet's text to a JSON endpoint
+// and merges the decoded response object into Tweet.Annotations.
+type HTTPEnricher struct {
+	// URL is the endpoint to POST {"text": "..."} to.
+	URL string
+	// Client is used to make the request. Defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+// Enrich implements Enricher.
+func (e HTTPEnricher) Enrich(ctx context.Context, t *Tweet) error {
+	client := e.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	body, err := json.Marshal(struct {
+		Text string `json:"text"`
+	}{t.Text})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", e.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("twitterfeed: enrich request to %s returned status %d", e.URL, resp.StatusCode)
+	}
+	var annotations map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&annotations); err != nil {
+		return err
+	}
+	if t.Annotations == nil {
+		t.Annotations = make(map[string]interface{}, len(annotations))
+	}
+	for k, v := range annotations {
+		t.Annotations[k] = v
+	}
+	return nil
+}
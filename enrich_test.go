@@ -0,0 +1,66 @@
+package twitterfeed
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type fakeEnricher struct {
+	err error
+}
+
+func (f fakeEnricher) Enrich(ctx context.Context, t *Tweet) error {
+	t.Annotations = map[string]interface{}{"ok": true}
+	return f.err
+}
+
+type recordingObserver struct {
+	calls []string
+	errs  []error
+}
+
+func (o *recordingObserver) ObserveEnrich(enricher string, d time.Duration, err error) {
+	o.calls = append(o.calls, enricher)
+	o.errs = append(o.errs, err)
+}
+
+func TestEnrichUsesObserverWhenSet(t *testing.T) {
+	obs := &recordingObserver{}
+	r := newTweetReader(WithObserver(obs))
+	r.Use(fakeEnricher{})
+
+	in := make(chan Tweet, 1)
+	in <- Tweet{Text: "hello"}
+	close(in)
+
+	out := r.enrich(context.Background(), in)
+	got := <-out
+	if got.Annotations["ok"] != true {
+		t.Fatalf("tweet was not enriched: %+v", got)
+	}
+	if len(obs.calls) != 1 {
+		t.Fatalf("ObserveEnrich called %d times, want 1", len(obs.calls))
+	}
+}
+
+func TestEnrichFallsBackToExpvar(t *testing.T) {
+	r := newTweetReader()
+	r.Use(fakeEnricher{err: errors.New("boom")})
+
+	in := make(chan Tweet, 1)
+	in <- Tweet{Text: "hello"}
+	close(in)
+
+	out := r.enrich(context.Background(), in)
+	<-out
+
+	const name = "twitterfeed.fakeEnricher"
+	if v := enrichMetrics.Get(name + "_calls"); v == nil {
+		t.Fatalf("expvar fallback did not record a call for %s", name)
+	}
+	if v := enrichMetrics.Get(name + "_errors"); v == nil {
+		t.Fatalf("expvar fallback did not record the error for %s", name)
+	}
+}
@@ -0,0 +1,68 @@
+package twitterfeed
+
+import "encoding/json"
+
+// StreamEvent is a control message sent by the filter stream out-of-band
+// from tweets: a deletion notice, a rate-limit notice, a stall warning, or
+// a disconnect notice. Exactly one field is set.
+type StreamEvent struct {
+	Delete     *DeleteEvent
+	Limit      *LimitEvent
+	Disconnect *DisconnectEvent
+	Warning    *WarningEvent
+}
+
+// DeleteEvent notifies that a tweet was deleted and should be discarded by
+// conforming applications.
+type DeleteEvent struct {
+	Status struct {
+		ID     int64 `json:"id"`
+		UserID int64 `json:"user_id"`
+	} `json:"status"`
+}
+
+// LimitEvent notifies that matching tweets were dropped because the
+// filter matched more tweets than the stream could deliver.
+type LimitEvent struct {
+	Track int64 `json:"track"`
+}
+
+// DisconnectEvent notifies that Twitter is about to close the connection.
+type DisconnectEvent struct {
+	Code       int    `json:"code"`
+	StreamName string `json:"stream_name"`
+	Reason     string `json:"reason"`
+}
+
+// WarningEvent notifies of a non-fatal condition, such as the stream
+// falling behind (stall_warnings).
+type WarningEvent struct {
+	Code        string  `json:"code"`
+	Message     string  `json:"message"`
+	PercentFull float64 `json:"percent_full"`
+}
+
+// parseStreamEvent reports whether line is a stream control message rather
+// than a tweet, decoding it into a StreamEvent if so.
+func parseStreamEvent(line []byte) (StreamEvent, bool) {
+	var probe struct {
+		Delete     *DeleteEvent     `json:"delete"`
+		Limit      *LimitEvent      `json:"limit"`
+		Disconnect *DisconnectEvent `json:"disconnect"`
+		Warning    *WarningEvent    `json:"warning"`
+	}
+	if err := json.Unmarshal(line, &probe); err != nil {
+		return StreamEvent{}, false
+	}
+	switch {
+	case probe.Delete != nil:
+		return StreamEvent{Delete: probe.Delete}, true
+	case probe.Limit != nil:
+		return StreamEvent{Limit: probe.Limit}, true
+	case probe.Disconnect != nil:
+		return StreamEvent{Disconnect: probe.Disconnect}, true
+	case probe.Warning != nil:
+		return StreamEvent{Warning: probe.Warning}, true
+	}
+	return StreamEvent{}, false
+}
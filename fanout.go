@@ -0,0 +1,160 @@
+package twitterfeed
+
+import "context"
+
+// Policy controls what happens to a subscriber's channel when it falls
+// behind the rate tweets are produced.
+type Policy int
+
+const (
+	// PolicyBlock blocks the sender until the slow subscriber catches up.
+	// This is the zero value, matching the package's original behavior.
+	PolicyBlock Policy = iota
+	// PolicyDropOldest discards the oldest buffered tweet to make room
+	// for the new one.
+	PolicyDropOldest
+	// PolicyDropNewest discards the incoming tweet, keeping what's
+	// already buffered.
+	PolicyDropNewest
+)
+
+// RunOptions controls the buffering and backpressure behavior of the
+// channel returned by Run, RunWithOptions, RunPolling and Subscribe.
+type RunOptions struct {
+	// BufferSize is the channel's buffer capacity. Zero means unbuffered,
+	// matching the package's original behavior.
+	BufferSize int
+	// OnDrop, if set, is called with each tweet dropped under
+	// PolicyDropOldest or PolicyDropNewest.
+	OnDrop func(Tweet)
+	// SlowConsumerPolicy selects what happens when this subscriber's
+	// buffer is full. The zero value is PolicyBlock.
+	SlowConsumerPolicy Policy
+}
+
+// WithRunOptions sets the default RunOptions used by Run, RunWithOptions,
+// RunPolling and Subscribe.
+func WithRunOptions(opts RunOptions) Option {
+	return func(r *TweetReader) {
+		r.runOpts = opts
+	}
+}
+
+// subscriber is one consumer of the fan-out pump started by Run,
+// RunWithOptions or RunPolling.
+type subscriber struct {
+	ch   chan Tweet
+	opts RunOptions
+	done chan struct{}
+}
+
+// Subscribe adds a new consumer of the tweets already being read by this
+// TweetReader's Run/RunWithOptions/RunPolling call, using the default
+// RunOptions set via WithRunOptions. Twitter allows only one filter stream
+// per credential set, so Subscribe lets multiple consumers share the one
+// connection instead of each opening their own. The returned func
+// unsubscribes, without affecting the underlying stream or other
+// subscribers.
+func (r *TweetReader) Subscribe() (<-chan Tweet, func()) {
+	return r.SubscribeWithOptions(r.runOpts)
+}
+
+// SubscribeWithOptions is Subscribe with a per-subscription RunOptions,
+// letting each consumer pick its own buffer size and slow consumer policy.
+func (r *TweetReader) SubscribeWithOptions(opts RunOptions) (<-chan Tweet, func()) {
+	sub := &subscriber{
+		ch:   make(chan Tweet, opts.BufferSize),
+		opts: opts,
+		done: make(chan struct{}),
+	}
+	r.subsMu.Lock()
+	if r.subs == nil {
+		r.subs = make(map[*subscriber]struct{})
+	}
+	r.subs[sub] = struct{}{}
+	r.subsMu.Unlock()
+	unsubscribe := func() {
+		r.subsMu.Lock()
+		delete(r.subs, sub)
+		r.subsMu.Unlock()
+		close(sub.done)
+	}
+	return sub.ch, unsubscribe
+}
+
+// broadcast starts the fan-out pump: it reads tweets from source,
+// delivering each to every current subscriber (including the one
+// Run/RunWithOptions/RunPolling registers for their own returned
+// channel), and returns that registered channel. Subscribers that fall
+// behind are handled per their own RunOptions; a PolicyBlock subscriber
+// holds up delivery to every subscriber until it catches up.
+func (r *TweetReader) broadcast(ctx context.Context, source <-chan Tweet) <-chan Tweet {
+	ch, _ := r.SubscribeWithOptions(r.runOpts)
+	go func() {
+		for t := range source {
+			r.subsMu.Lock()
+			subs := make([]*subscriber, 0, len(r.subs))
+			for sub := range r.subs {
+				subs = append(subs, sub)
+			}
+			r.subsMu.Unlock()
+			for _, sub := range subs {
+				deliver(ctx, sub, t)
+			}
+		}
+		// source is drained (the stream ended or ctx was cancelled); close
+		// every subscriber still registered, including our own default one,
+		// so range loops over Run/RunWithOptions/RunPolling's returned
+		// channel terminate.
+		r.subsMu.Lock()
+		for sub := range r.subs {
+			delete(r.subs, sub)
+			close(sub.ch)
+		}
+		r.subsMu.Unlock()
+	}()
+	return ch
+}
+
+// deliver sends t to sub.ch according to sub.opts.SlowConsumerPolicy,
+// without blocking on a subscriber that has unsubscribed.
+func deliver(ctx context.Context, sub *subscriber, t Tweet) {
+	switch sub.opts.SlowConsumerPolicy {
+	case PolicyDropNewest:
+		select {
+		case sub.ch <- t:
+		case <-sub.done:
+		default:
+			if sub.opts.OnDrop != nil {
+				sub.opts.OnDrop(t)
+			}
+		}
+	case PolicyDropOldest:
+		select {
+		case sub.ch <- t:
+		case <-sub.done:
+		default:
+			select {
+			case old := <-sub.ch:
+				if sub.opts.OnDrop != nil {
+					sub.opts.OnDrop(old)
+				}
+			default:
+			}
+			select {
+			case sub.ch <- t:
+			case <-sub.done:
+			default:
+				if sub.opts.OnDrop != nil {
+					sub.opts.OnDrop(t)
+				}
+			}
+		}
+	default: // PolicyBlock
+		select {
+		case sub.ch <- t:
+		case <-sub.done:
+		case <-ctx.Done():
+		}
+	}
+}
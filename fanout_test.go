@@ -0,0 +1,61 @@
+package twitterfeed
+
+import (
+	"context"
+	"testing"
+)
+
+func TestDeliverPolicyBlock(t *testing.T) {
+	sub := &subscriber{ch: make(chan Tweet, 1), done: make(chan struct{})}
+	deliver(context.Background(), sub, Tweet{ID: 1})
+	if got := <-sub.ch; got.ID != 1 {
+		t.Fatalf("got tweet %d, want 1", got.ID)
+	}
+}
+
+func TestDeliverPolicyBlockUnblocksOnUnsubscribe(t *testing.T) {
+	sub := &subscriber{ch: make(chan Tweet), done: make(chan struct{})}
+	close(sub.done)
+	done := make(chan struct{})
+	go func() {
+		deliver(context.Background(), sub, Tweet{ID: 1})
+		close(done)
+	}()
+	<-done // must not hang: PolicyBlock still respects sub.done.
+}
+
+func TestDeliverPolicyDropNewest(t *testing.T) {
+	var dropped []Tweet
+	sub := &subscriber{
+		ch:   make(chan Tweet, 1),
+		done: make(chan struct{}),
+		opts: RunOptions{SlowConsumerPolicy: PolicyDropNewest, OnDrop: func(t Tweet) { dropped = append(dropped, t) }},
+	}
+	deliver(context.Background(), sub, Tweet{ID: 1}) // fills the buffer
+	deliver(context.Background(), sub, Tweet{ID: 2}) // buffer full: dropped
+
+	if got := <-sub.ch; got.ID != 1 {
+		t.Fatalf("buffered tweet = %d, want 1 (oldest kept)", got.ID)
+	}
+	if len(dropped) != 1 || dropped[0].ID != 2 {
+		t.Fatalf("dropped = %+v, want [{ID:2}] (incoming tweet)", dropped)
+	}
+}
+
+func TestDeliverPolicyDropOldest(t *testing.T) {
+	var dropped []Tweet
+	sub := &subscriber{
+		ch:   make(chan Tweet, 1),
+		done: make(chan struct{}),
+		opts: RunOptions{SlowConsumerPolicy: PolicyDropOldest, OnDrop: func(t Tweet) { dropped = append(dropped, t) }},
+	}
+	deliver(context.Background(), sub, Tweet{ID: 1}) // fills the buffer
+	deliver(context.Background(), sub, Tweet{ID: 2}) // buffer full: oldest (1) dropped, 2 buffered
+
+	if got := <-sub.ch; got.ID != 2 {
+		t.Fatalf("buffered tweet = %d, want 2 (newest kept)", got.ID)
+	}
+	if len(dropped) != 1 || dropped[0].ID != 1 {
+		t.Fatalf("dropped = %+v, want [{ID:1}] (oldest buffered tweet)", dropped)
+	}
+}
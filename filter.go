@@ -0,0 +1,80 @@
+package twitterfeed
+
+import (
+	"fmt"
+	"time"
+)
+
+// Mode selects how a TweetReader fetches tweets.
+type Mode int
+
+const (
+	// ModeStream connects to statuses/filter.json and streams tweets as
+	// they're matched. This is the default.
+	ModeStream Mode = iota
+	// ModePolling polls search/tweets.json at FilterOptions.PollInterval.
+	ModePolling
+	// ModeAuto tries ModeStream and transparently falls back to
+	// ModePolling if the stream is forbidden (403), which happens on API
+	// tiers without streaming access.
+	ModeAuto
+)
+
+// defaultPollInterval is used when FilterOptions.PollInterval is zero.
+const defaultPollInterval = 60 * time.Second
+
+// Twitter's documented limits for the statuses/filter.json parameters.
+// https://developer.twitter.com/en/docs/twitter-api/v1/tweets/filter-realtime/api-reference/post-statuses-filter
+const (
+	maxTrackTerms    = 400
+	maxFollowIDs     = 5000
+	maxLocationBoxes = 25
+)
+
+// BoundingBox is a geographic bounding box, specified as the longitude and
+// latitude of its south-west and north-east corners, used to filter tweets
+// by location.
+type BoundingBox struct {
+	SWLng, SWLat float64
+	NELng, NELat float64
+}
+
+// FilterOptions describes a statuses/filter.json request.
+type FilterOptions struct {
+	// Track is a list of keywords to track.
+	Track []string
+	// Follow is a list of user IDs whose tweets should be returned.
+	Follow []int64
+	// Locations is a list of bounding boxes to filter tweets by location.
+	Locations []BoundingBox
+	// Languages restricts tweets to the given BCP 47 language identifiers.
+	Languages []string
+	// StallWarnings enables stall_warnings messages on the Events channel.
+	StallWarnings bool
+	// Mode selects how tweets are fetched. The zero value is ModeStream.
+	Mode Mode
+	// PollInterval is how often ModePolling and ModeAuto poll
+	// search/tweets.json. Defaults to 60 seconds.
+	PollInterval time.Duration
+	// Rules are v2 filtered stream rule strings (e.g. "from:twitterdev
+	// -has:media"), used instead of Track when the TweetReader
+	// authenticates with a bearer token. Each rule is managed as its own
+	// rule via POST /2/tweets/search/stream/rules. If empty, Track's terms
+	// are combined into a single OR'd rule.
+	Rules []string
+}
+
+// validate checks opts against Twitter's documented limits for the
+// statuses/filter.json parameters.
+func (opts FilterOptions) validate() error {
+	if len(opts.Track) > maxTrackTerms {
+		return fmt.Errorf("twitterfeed: track has %d terms, exceeds the limit of %d", len(opts.Track), maxTrackTerms)
+	}
+	if len(opts.Follow) > maxFollowIDs {
+		return fmt.Errorf("twitterfeed: follow has %d ids, exceeds the limit of %d", len(opts.Follow), maxFollowIDs)
+	}
+	if len(opts.Locations) > maxLocationBoxes {
+		return fmt.Errorf("twitterfeed: locations has %d boxes, exceeds the limit of %d", len(opts.Locations), maxLocationBoxes)
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package twitterfeed
+
+import "testing"
+
+func TestFilterOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    FilterOptions
+		wantErr bool
+	}{
+		{"empty", FilterOptions{}, false},
+		{"track at limit", FilterOptions{Track: make([]string, maxTrackTerms)}, false},
+		{"track over limit", FilterOptions{Track: make([]string, maxTrackTerms+1)}, true},
+		{"follow at limit", FilterOptions{Follow: make([]int64, maxFollowIDs)}, false},
+		{"follow over limit", FilterOptions{Follow: make([]int64, maxFollowIDs+1)}, true},
+		{"locations at limit", FilterOptions{Locations: make([]BoundingBox, maxLocationBoxes)}, false},
+		{"locations over limit", FilterOptions{Locations: make([]BoundingBox, maxLocationBoxes+1)}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.validate()
+			if tt.wantErr && err == nil {
+				t.Fatal("validate: want error, got nil")
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("validate: %v", err)
+			}
+		})
+	}
+}
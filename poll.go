@@ -0,0 +1,251 @@
+package twitterfeed
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/garyburd/go-oauth/oauth"
+)
+
+// searchResponse is the relevant part of a search/tweets.json response.
+type searchResponse struct {
+	Statuses []Tweet `json:"statuses"`
+}
+
+// runPolling polls search/tweets.json (or, for bearer/v2 readers, the v2
+// search/recent endpoint) at opts.PollInterval, tracking since_id between
+// polls to avoid duplicates, and sends decoded tweets into tweetsChan
+// until ctx is done.
+func (r *TweetReader) runPolling(ctx context.Context, opts FilterOptions, tweetsChan chan Tweet) {
+	defer close(tweetsChan)
+	interval := opts.PollInterval
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	client := &http.Client{}
+	var sinceID int64
+	for {
+		tweets, resetWait, err := r.pollOnce(ctx, client, opts, sinceID)
+		if err != nil {
+			log.Println(err)
+			if !sleep(ctx, interval) {
+				return
+			}
+			continue
+		}
+		// tweets is oldest-first; track the highest ID seen as the
+		// since_id for the next poll.
+		for _, t := range tweets {
+			if t.ID > sinceID {
+				sinceID = t.ID
+			}
+			matchText := t.Text
+			if t.ExtendedTweet != nil && t.ExtendedTweet.FullText != "" {
+				matchText = t.ExtendedTweet.FullText
+			}
+			t.Terms = foundTerms(matchText, opts.Track...)
+			select {
+			case tweetsChan <- t:
+			case <-ctx.Done():
+				return
+			}
+		}
+		wait := interval
+		if resetWait > wait {
+			wait = resetWait
+		}
+		if !sleep(ctx, wait) {
+			return
+		}
+	}
+}
+
+// pollOnce makes a single search request and returns any new tweets in
+// oldest-first order, plus how long to wait before the next poll per the
+// response's rate limit headers. It dispatches to the v1.1
+// search/tweets.json endpoint or, for bearer/v2 readers, v2's
+// search/recent.
+func (r *TweetReader) pollOnce(ctx context.Context, client *http.Client, opts FilterOptions, sinceID int64) ([]Tweet, time.Duration, error) {
+	if r.v2 {
+		return r.pollOnceV2(ctx, client, opts, sinceID, false)
+	}
+	req, err := newSearchRequest(r, opts, sinceID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("twitterfeed: creating search request: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("twitterfeed: search request failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, 0, fmt.Errorf("twitterfeed: search request returned status %d", resp.StatusCode)
+	}
+	var result searchResponse
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	resetWait := rateLimitWait(resp)
+	resp.Body.Close()
+	if err != nil {
+		return nil, resetWait, fmt.Errorf("twitterfeed: decoding search response: %w", err)
+	}
+	// statuses are returned newest-first; reverse to oldest-first.
+	tweets := make([]Tweet, len(result.Statuses))
+	for i, t := range result.Statuses {
+		tweets[len(result.Statuses)-1-i] = t
+	}
+	return tweets, resetWait, nil
+}
+
+// pollOnceV2 is pollOnce's v2 search/recent counterpart. forceRefresh
+// retries once with a freshly-fetched bearer token after a 401, the same
+// as doStreamV2Request.
+func (r *TweetReader) pollOnceV2(ctx context.Context, client *http.Client, opts FilterOptions, sinceID int64, forceRefresh bool) ([]Tweet, time.Duration, error) {
+	req, err := r.newSearchRequestV2(ctx, opts, sinceID, forceRefresh)
+	if err != nil {
+		return nil, 0, fmt.Errorf("twitterfeed: creating search request: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("twitterfeed: search request failed: %w", err)
+	}
+	if resp.StatusCode == http.StatusUnauthorized && !forceRefresh {
+		resp.Body.Close()
+		return r.pollOnceV2(ctx, client, opts, sinceID, true)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, 0, fmt.Errorf("twitterfeed: search request returned status %d", resp.StatusCode)
+	}
+	var result struct {
+		Data     []v2TweetData `json:"data"`
+		Includes v2Includes    `json:"includes"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	resetWait := rateLimitWait(resp)
+	resp.Body.Close()
+	if err != nil {
+		return nil, resetWait, fmt.Errorf("twitterfeed: decoding search response: %w", err)
+	}
+	// data is returned newest-first; reverse to oldest-first.
+	tweets := make([]Tweet, len(result.Data))
+	for i, data := range result.Data {
+		tweets[len(result.Data)-1-i] = buildV2Tweet(data, result.Includes)
+	}
+	return tweets, resetWait, nil
+}
+
+// sleep blocks for d, or until ctx is done, reporting whether it returned
+// because d elapsed (true) rather than ctx being cancelled (false).
+func sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
+
+// rateLimitWait returns how long to wait before the next request based on
+// resp's x-rate-limit-remaining/x-rate-limit-reset headers, or 0 if the
+// rate limit isn't exhausted.
+func rateLimitWait(resp *http.Response) time.Duration {
+	remaining, err := strconv.Atoi(resp.Header.Get("x-rate-limit-remaining"))
+	if err != nil || remaining > 0 {
+		return 0
+	}
+	reset, err := strconv.ParseInt(resp.Header.Get("x-rate-limit-reset"), 10, 64)
+	if err != nil {
+		return 0
+	}
+	wait := time.Until(time.Unix(reset, 0))
+	if wait < 0 {
+		return 0
+	}
+	return wait
+}
+
+// newSearchRequest builds an OAuth1-signed search/tweets.json request.
+func newSearchRequest(r *TweetReader, opts FilterOptions, sinceID int64) (*http.Request, error) {
+	form := url.Values{}
+	if len(opts.Track) > 0 {
+		form.Set("q", strings.Join(opts.Track, " OR "))
+	}
+	if len(opts.Languages) > 0 {
+		form.Set("lang", strings.Join(opts.Languages, ","))
+	}
+	if sinceID > 0 {
+		form.Set("since_id", strconv.FormatInt(sinceID, 10))
+	}
+	form.Set("result_type", "recent")
+	u, _ := url.Parse("https://api.twitter.com/1.1/search/tweets.json")
+	u.RawQuery = form.Encode()
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	creds := &oauth.Credentials{
+		Token:  r.accessToken,
+		Secret: r.accessSecret,
+	}
+	authClient := &oauth.Client{
+		Credentials: oauth.Credentials{
+			Token:  r.consumerKey,
+			Secret: r.consumerSecret,
+		},
+	}
+	req.Header.Set("Authorization", authClient.AuthorizationHeader(creds, "GET", u, form))
+	return req, nil
+}
+
+// newSearchRequestV2 builds a bearer-authenticated v2 search/recent
+// request. If forceRefresh is set, the bearer token is refreshed before
+// building the request, for the single retry after a 401.
+func (r *TweetReader) newSearchRequestV2(ctx context.Context, opts FilterOptions, sinceID int64, forceRefresh bool) (*http.Request, error) {
+	var token string
+	var err error
+	if forceRefresh {
+		token, err = r.refreshBearer(ctx)
+	} else {
+		token, err = r.bearer(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var query []string
+	if len(opts.Track) > 0 {
+		query = append(query, strings.Join(opts.Track, " OR "))
+	}
+	if len(opts.Languages) > 0 {
+		langs := make([]string, len(opts.Languages))
+		for i, lang := range opts.Languages {
+			langs[i] = "lang:" + lang
+		}
+		query = append(query, "("+strings.Join(langs, " OR ")+")")
+	}
+	q := url.Values{}
+	q.Set("query", strings.Join(query, " "))
+	if sinceID > 0 {
+		q.Set("since_id", strconv.FormatInt(sinceID, 10))
+	}
+	q.Set("tweet.fields", v2TweetFields)
+	q.Set("expansions", v2Expansions)
+	q.Set("user.fields", v2UserFields)
+	u, _ := url.Parse("https://api.twitter.com/2/tweets/search/recent")
+	u.RawQuery = q.Encode()
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Authorization", "Bearer "+token)
+	return req, nil
+}
@@ -0,0 +1,43 @@
+package twitterfeed
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRateLimitWait(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name      string
+		remaining string
+		reset     string
+		wantZero  bool
+	}{
+		{"remaining left", "5", strconv.FormatInt(now.Add(time.Minute).Unix(), 10), true},
+		{"remaining missing", "", strconv.FormatInt(now.Add(time.Minute).Unix(), 10), true},
+		{"reset missing", "0", "", true},
+		{"reset in the past", "0", strconv.FormatInt(now.Add(-time.Minute).Unix(), 10), true},
+		{"exhausted", "0", strconv.FormatInt(now.Add(time.Minute).Unix(), 10), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{Header: http.Header{}}
+			if tt.remaining != "" {
+				resp.Header.Set("x-rate-limit-remaining", tt.remaining)
+			}
+			if tt.reset != "" {
+				resp.Header.Set("x-rate-limit-reset", tt.reset)
+			}
+			wait := rateLimitWait(resp)
+			if tt.wantZero && wait != 0 {
+				t.Fatalf("rateLimitWait = %v, want 0", wait)
+			}
+			if !tt.wantZero && wait <= 0 {
+				t.Fatalf("rateLimitWait = %v, want > 0", wait)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package twitterfeed
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Tweet is a single tweet, decoded from Twitter's filter stream payload.
+type Tweet struct {
+	// ID is the tweet's unique identifier.
+	ID int64 `json:"id"`
+	// CreatedAt is when the tweet was posted.
+	CreatedAt time.Time `json:"created_at"`
+	// Text is the body of the tweet. For tweets longer than 140 characters,
+	// this is truncated; see ExtendedTweet for the full text.
+	Text string `json:"text"`
+	// Lang is the BCP 47 language identifier Twitter detected for the tweet.
+	Lang string `json:"lang"`
+	// User is the author of the tweet.
+	User *User `json:"user"`
+	// Entities holds hashtags, URLs and user mentions parsed out of Text.
+	Entities Entities `json:"entities"`
+	// ExtendedTweet holds the full text and entities for tweets over 140
+	// characters (the "280-char" case).
+	ExtendedTweet *ExtendedTweet `json:"extended_tweet"`
+	// Coordinates is the geographic location the tweet was sent from, if
+	// the user enabled it.
+	Coordinates *Coordinates `json:"coordinates"`
+	// RetweetedStatus is the original tweet, if this is a retweet.
+	RetweetedStatus *Tweet `json:"retweeted_status"`
+	// QuotedStatus is the tweet being quoted, if this is a quote tweet.
+	QuotedStatus *Tweet `json:"quoted_status"`
+
+	// Terms is a list of matching terms found in the tweet text.
+	Terms []string `json:"-"`
+	// Annotations holds results merged in by any Enrichers the
+	// TweetReader was configured with via Use.
+	Annotations map[string]interface{} `json:"-"`
+}
+
+// User is the author of a Tweet.
+type User struct {
+	ID              int64  `json:"id"`
+	Name            string `json:"name"`
+	ScreenName      string `json:"screen_name"`
+	Location        string `json:"location"`
+	Description     string `json:"description"`
+	FollowersCount  int    `json:"followers_count"`
+	FriendsCount    int    `json:"friends_count"`
+	Verified        bool   `json:"verified"`
+	ProfileImageURL string `json:"profile_image_url_https"`
+}
+
+// Entities holds the hashtags, URLs and user mentions parsed out of a
+// tweet's text by Twitter.
+type Entities struct {
+	Hashtags     []HashtagEntity     `json:"hashtags"`
+	URLs         []URLEntity         `json:"urls"`
+	UserMentions []UserMentionEntity `json:"user_mentions"`
+}
+
+// HashtagEntity is a single #hashtag found in a tweet.
+type HashtagEntity struct {
+	Text    string `json:"text"`
+	Indices [2]int `json:"indices"`
+}
+
+// URLEntity is a single URL found in a tweet.
+type URLEntity struct {
+	URL         string `json:"url"`
+	ExpandedURL string `json:"expanded_url"`
+	DisplayURL  string `json:"display_url"`
+	Indices     [2]int `json:"indices"`
+}
+
+// UserMentionEntity is a single @mention found in a tweet.
+type UserMentionEntity struct {
+	ID         int64  `json:"id"`
+	Name       string `json:"name"`
+	ScreenName string `json:"screen_name"`
+	Indices    [2]int `json:"indices"`
+}
+
+// ExtendedTweet holds the full text and entities for a tweet that Twitter
+// truncated in the top-level Text field.
+type ExtendedTweet struct {
+	FullText string   `json:"full_text"`
+	Entities Entities `json:"entities"`
+}
+
+// Coordinates is a geographic location attached to a tweet.
+type Coordinates struct {
+	Type string `json:"type"`
+	// Coordinates holds [longitude, latitude], as provided by Twitter.
+	Coordinates [2]float64 `json:"coordinates"`
+}
+
+// twitterTimeLayout is the format Twitter uses for created_at timestamps,
+// e.g. "Mon Jan 02 15:04:05 -0700 2006".
+const twitterTimeLayout = time.RubyDate
+
+// UnmarshalJSON decodes a Tweet, parsing CreatedAt using Twitter's
+// non-standard timestamp format.
+func (t *Tweet) UnmarshalJSON(data []byte) error {
+	type alias Tweet
+	aux := &struct {
+		CreatedAt string `json:"created_at"`
+		*alias
+	}{
+		alias: (*alias)(t),
+	}
+	if err := json.Unmarshal(data, aux); err != nil {
+		return err
+	}
+	if aux.CreatedAt != "" {
+		ct, err := time.Parse(twitterTimeLayout, aux.CreatedAt)
+		if err != nil {
+			return err
+		}
+		t.CreatedAt = ct
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package twitterfeed
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTweetUnmarshalJSON(t *testing.T) {
+	const payload = `{
+		"id": 42,
+		"text": "hello",
+		"lang": "en",
+		"created_at": "Thu Apr 06 15:24:15 +0000 2017"
+	}`
+	var tw Tweet
+	if err := tw.UnmarshalJSON([]byte(payload)); err != nil {
+		t.Fatalf("UnmarshalJSON: %v", err)
+	}
+	if tw.ID != 42 || tw.Text != "hello" || tw.Lang != "en" {
+		t.Fatalf("unexpected tweet: %+v", tw)
+	}
+	want := time.Date(2017, time.April, 6, 15, 24, 15, 0, time.UTC)
+	if !tw.CreatedAt.Equal(want) {
+		t.Fatalf("CreatedAt = %v, want %v", tw.CreatedAt, want)
+	}
+}
+
+func TestTweetUnmarshalJSONNoCreatedAt(t *testing.T) {
+	var tw Tweet
+	if err := tw.UnmarshalJSON([]byte(`{"id": 1, "text": "hi"}`)); err != nil {
+		t.Fatalf("UnmarshalJSON: %v", err)
+	}
+	if !tw.CreatedAt.IsZero() {
+		t.Fatalf("CreatedAt = %v, want zero value", tw.CreatedAt)
+	}
+}
+
+func TestTweetUnmarshalJSONBadCreatedAt(t *testing.T) {
+	var tw Tweet
+	err := tw.UnmarshalJSON([]byte(`{"id": 1, "text": "hi", "created_at": "not a time"}`))
+	if err == nil {
+		t.Fatal("UnmarshalJSON: want error for malformed created_at")
+	}
+	if !strings.Contains(err.Error(), "cannot parse") {
+		t.Fatalf("UnmarshalJSON error = %v, want a time-parsing error", err)
+	}
+}
+
+func TestJSONDecoderDecode(t *testing.T) {
+	r := strings.NewReader(`{"id": 7, "text": "world"}`)
+	tw, err := jsonDecoder{}.Decode(r)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if tw.ID != 7 || tw.Text != "world" {
+		t.Fatalf("unexpected tweet: %+v", tw)
+	}
+}
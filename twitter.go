@@ -2,8 +2,8 @@ package twitterfeed
 
 import (
 	"bufio"
+	"bytes"
 	"context"
-	"encoding/json"
 	"log"
 	"net"
 	"net/http"
@@ -16,33 +16,180 @@ import (
 	"github.com/garyburd/go-oauth/oauth"
 )
 
-// Tweet is a single tweet.
-type Tweet struct {
-	// Text is the body of the tweet.
-	Text string
-	// Terms is a list of matching terms in the text.
-	Terms []string
-}
-
 // TweetReader reads tweets.
 type TweetReader struct {
 	consumerKey, consumerSecret, accessToken, accessSecret string
+	backoff                                                *backoff
+	decoder                                                Decoder
+	events                                                 chan StreamEvent
+
+	// v2 is true when the reader authenticates with an app-only bearer
+	// token and should use the v2 filtered stream endpoints instead of
+	// the v1.1 OAuth1 ones.
+	v2 bool
+
+	bearerMu     sync.RWMutex
+	bearerToken  string
+	bearerExpiry time.Time
+	bearerTTL    time.Duration
+	clientID     string
+	clientSecret string
+
+	enrichers         []Enricher
+	enrichConcurrency int
+	enrichBuffer      int
+	enrichDropped     int64
+	observer          Observer
+
+	runOpts RunOptions
+	subsMu  sync.Mutex
+	subs    map[*subscriber]struct{}
+}
+
+// Option configures a TweetReader.
+type Option func(*TweetReader)
+
+// WithBackoff overrides the default reconnection backoff policy.
+func WithBackoff(b *backoff) Option {
+	return func(r *TweetReader) {
+		r.backoff = b
+	}
 }
 
-// NewTweetReader creates a new TweetReader with the given credentials.
-func NewTweetReader(consumerKey, consumerSecret, accessToken, accessSecret string) *TweetReader {
-	return &TweetReader{
-		consumerKey:    consumerKey,
-		consumerSecret: consumerSecret,
-		accessToken:    accessToken,
-		accessSecret:   accessSecret,
+// newTweetReader builds a TweetReader with its defaults applied, shared by
+// all the New* constructors.
+func newTweetReader(opts ...Option) *TweetReader {
+	r := &TweetReader{
+		backoff: &backoff{},
+		decoder: jsonDecoder{},
+		events:  make(chan StreamEvent, 16),
 	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// NewTweetReader creates a new TweetReader with the given OAuth1
+// user-context credentials.
+func NewTweetReader(consumerKey, consumerSecret, accessToken, accessSecret string, opts ...Option) *TweetReader {
+	r := newTweetReader(opts...)
+	r.consumerKey = consumerKey
+	r.consumerSecret = consumerSecret
+	r.accessToken = accessToken
+	r.accessSecret = accessSecret
+	return r
+}
+
+// Events returns a channel of stream control messages (deletions, rate
+// limit notices, stall warnings and disconnect notices) received
+// out-of-band from tweets. The reader never blocks on it: once its buffer
+// of 16 is full, further events are dropped until it is drained.
+func (r *TweetReader) Events() <-chan StreamEvent {
+	return r.events
 }
 
-// Run starts reading and returns a channel through which Tweet objects are sent.
-// Use a cancel function or timeout on the context to terminate the reader.
+// Run starts reading and returns a channel through which Tweet objects are
+// sent. Use a cancel function or timeout on the context to terminate the
+// reader. It is a thin wrapper around RunWithOptions using terms as the
+// track list.
 func (r *TweetReader) Run(ctx context.Context, terms ...string) <-chan Tweet {
+	tweetsChan, err := r.RunWithOptions(ctx, FilterOptions{Track: terms})
+	if err != nil {
+		// the only validation RunWithOptions can fail here is more terms
+		// than the track limit; Run then returns an already-closed channel
+		// so its signature can stay simple.
+		ch := make(chan Tweet)
+		close(ch)
+		return ch
+	}
+	return tweetsChan
+}
+
+// RunWithOptions starts reading with the given FilterOptions and returns a
+// channel through which Tweet objects are sent. Use a cancel function or
+// timeout on the context to terminate the reader. It returns an error
+// immediately, without connecting, if opts exceeds Twitter's documented
+// limits.
+//
+// opts.Mode selects how tweets are fetched: ModeStream (the default) opens
+// statuses/filter.json, ModePolling polls search/tweets.json, and ModeAuto
+// tries streaming and falls back to polling if the stream is forbidden.
+func (r *TweetReader) RunWithOptions(ctx context.Context, opts FilterOptions) (<-chan Tweet, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
 	tweetsChan := make(chan Tweet)
+	switch opts.Mode {
+	case ModePolling:
+		go r.runPolling(ctx, opts, tweetsChan)
+	case ModeAuto:
+		go r.runAuto(ctx, opts, tweetsChan)
+	default:
+		if r.v2 {
+			go r.runStreamV2(ctx, opts, tweetsChan)
+		} else {
+			go r.runStream(ctx, opts, tweetsChan)
+		}
+	}
+	return r.broadcast(ctx, r.finalize(ctx, tweetsChan)), nil
+}
+
+// RunPolling starts reading by polling search/tweets.json and returns a
+// channel through which Tweet objects are sent. Use a cancel function or
+// timeout on the context to terminate the reader.
+func (r *TweetReader) RunPolling(ctx context.Context, opts FilterOptions) (<-chan Tweet, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	tweetsChan := make(chan Tweet)
+	go r.runPolling(ctx, opts, tweetsChan)
+	return r.broadcast(ctx, r.finalize(ctx, tweetsChan)), nil
+}
+
+// runAuto tries the filter stream and transparently falls back to polling
+// if the stream is forbidden (403), which happens on API tiers without
+// streaming access.
+func (r *TweetReader) runAuto(ctx context.Context, opts FilterOptions, tweetsChan chan Tweet) {
+	if status, err := r.probeStream(ctx, opts); err == nil && status == http.StatusForbidden {
+		log.Println("stream forbidden, falling back to polling")
+		r.runPolling(ctx, opts, tweetsChan)
+		return
+	}
+	if r.v2 {
+		r.runStreamV2(ctx, opts, tweetsChan)
+		return
+	}
+	r.runStream(ctx, opts, tweetsChan)
+}
+
+// probeStream makes a single connection attempt to the filter stream and
+// returns its status code, to let runAuto decide whether to fall back to
+// polling without committing to a long-lived stream connection.
+func (r *TweetReader) probeStream(ctx context.Context, opts FilterOptions) (int, error) {
+	if r.v2 {
+		resp, status, err := r.doStreamV2Request(ctx, http.DefaultClient, false)
+		if err != nil {
+			return 0, err
+		}
+		resp.Body.Close()
+		return status, nil
+	}
+	req, err := newFilterRequest(r, opts)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// runStream connects to statuses/filter.json, reconnecting with r.backoff
+// on errors, and sends decoded tweets into tweetsChan until ctx is done.
+func (r *TweetReader) runStream(ctx context.Context, opts FilterOptions, tweetsChan chan Tweet) {
 	var connLock sync.Mutex
 	var conn net.Conn
 	client := &http.Client{
@@ -63,16 +210,6 @@ func (r *TweetReader) Run(ctx context.Context, terms ...string) <-chan Tweet {
 			},
 		},
 	}
-	creds := &oauth.Credentials{
-		Token:  r.accessToken,
-		Secret: r.accessSecret,
-	}
-	authClient := &oauth.Client{
-		Credentials: oauth.Credentials{
-			Token:  r.consumerKey,
-			Secret: r.consumerSecret,
-		},
-	}
 	go func() {
 		// periodically close the connection to keep it fresh,
 		// and if the context is done, close the connection and exit.
@@ -106,20 +243,18 @@ func (r *TweetReader) Run(ctx context.Context, terms ...string) <-chan Tweet {
 			case <-ctx.Done():
 				return
 			default:
-				form := url.Values{"track": {strings.Join(terms, ",")}}
-				formEnc := form.Encode()
-				u, _ := url.Parse("https://stream.twitter.com/1.1/statuses/filter.json")
-				req, err := http.NewRequest("POST", u.String(), strings.NewReader(formEnc))
+				req, err := newFilterRequest(r, opts)
 				if err != nil {
 					log.Println("creating filter request failed:", err)
 					continue
 				}
-				req.Header.Set("Authorization", authClient.AuthorizationHeader(creds, "POST", u, form))
-				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-				req.Header.Set("Content-Length", strconv.Itoa(len(formEnc)))
 				resp, err := client.Do(req)
 				if err != nil {
 					log.Println("Error getting response:", err)
+					r.backoff.OnNetErr()
+					if err := r.backoff.Wait(ctx); err != nil {
+						return
+					}
 					continue
 				}
 				if resp.StatusCode != http.StatusOK {
@@ -128,24 +263,115 @@ func (r *TweetReader) Run(ctx context.Context, terms ...string) <-chan Tweet {
 					s.Scan()
 					log.Println(s.Text())
 					log.Println("StatusCode =", resp.StatusCode)
+					resp.Body.Close()
+					r.backoff.OnHTTPErr(resp.StatusCode)
+					if err := r.backoff.Wait(ctx); err != nil {
+						return
+					}
 					continue
 				}
-				decoder := json.NewDecoder(resp.Body)
+				var gotMessage bool
 				func() {
 					defer resp.Body.Close()
-					for {
-						var t Tweet
-						if err := decoder.Decode(&t); err != nil {
-							break
+					scanner := bufio.NewScanner(resp.Body)
+					scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+					for scanner.Scan() {
+						line := bytes.TrimSpace(scanner.Bytes())
+						if len(line) == 0 {
+							// keep-alive newline
+							continue
+						}
+						if evt, ok := parseStreamEvent(line); ok {
+							select {
+							case r.events <- evt:
+							default:
+							}
+							continue
+						}
+						t, err := r.decoder.Decode(bytes.NewReader(line))
+						if err != nil {
+							log.Println("decoding tweet failed:", err)
+							continue
 						}
-						t.Terms = foundTerms(t.Text, terms...)
+						gotMessage = true
+						matchText := t.Text
+						if t.ExtendedTweet != nil && t.ExtendedTweet.FullText != "" {
+							matchText = t.ExtendedTweet.FullText
+						}
+						t.Terms = foundTerms(matchText, opts.Track...)
 						tweetsChan <- t
 					}
 				}()
+				if gotMessage {
+					r.backoff.OnSuccess()
+				} else {
+					r.backoff.OnNetErr()
+					if err := r.backoff.Wait(ctx); err != nil {
+						return
+					}
+				}
 			}
 		}
 	}()
-	return tweetsChan
+}
+
+// newFilterRequest builds an authenticated statuses/filter.json request.
+func newFilterRequest(r *TweetReader, opts FilterOptions) (*http.Request, error) {
+	form := filterForm(opts)
+	formEnc := form.Encode()
+	u, _ := url.Parse("https://stream.twitter.com/1.1/statuses/filter.json")
+	req, err := http.NewRequest("POST", u.String(), strings.NewReader(formEnc))
+	if err != nil {
+		return nil, err
+	}
+	creds := &oauth.Credentials{
+		Token:  r.accessToken,
+		Secret: r.accessSecret,
+	}
+	authClient := &oauth.Client{
+		Credentials: oauth.Credentials{
+			Token:  r.consumerKey,
+			Secret: r.consumerSecret,
+		},
+	}
+	req.Header.Set("Authorization", authClient.AuthorizationHeader(creds, "POST", u, form))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Content-Length", strconv.Itoa(len(formEnc)))
+	return req, nil
+}
+
+// filterForm builds the statuses/filter.json request parameters for opts.
+func filterForm(opts FilterOptions) url.Values {
+	form := url.Values{}
+	if len(opts.Track) > 0 {
+		form.Set("track", strings.Join(opts.Track, ","))
+	}
+	if len(opts.Follow) > 0 {
+		ids := make([]string, len(opts.Follow))
+		for i, id := range opts.Follow {
+			ids[i] = strconv.FormatInt(id, 10)
+		}
+		form.Set("follow", strings.Join(ids, ","))
+	}
+	if len(opts.Locations) > 0 {
+		coords := make([]string, 0, len(opts.Locations)*4)
+		for _, box := range opts.Locations {
+			coords = append(coords,
+				strconv.FormatFloat(box.SWLng, 'f', -1, 64),
+				strconv.FormatFloat(box.SWLat, 'f', -1, 64),
+				strconv.FormatFloat(box.NELng, 'f', -1, 64),
+				strconv.FormatFloat(box.NELat, 'f', -1, 64),
+			)
+		}
+		form.Set("locations", strings.Join(coords, ","))
+	}
+	if len(opts.Languages) > 0 {
+		form.Set("language", strings.Join(opts.Languages, ","))
+	}
+	if opts.StallWarnings {
+		form.Set("stall_warnings", "true")
+	}
+	return form
 }
 
 // foundTerms searches text for any of the terms and returns a list
@@ -0,0 +1,392 @@
+package twitterfeed
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	v2StreamURL = "https://api.twitter.com/2/tweets/search/stream"
+	v2RulesURL  = "https://api.twitter.com/2/tweets/search/stream/rules"
+)
+
+// v2Rule is a single v2 filtered stream rule.
+type v2Rule struct {
+	ID    string `json:"id,omitempty"`
+	Value string `json:"value"`
+	Tag   string `json:"tag,omitempty"`
+}
+
+// v2RulesResponse is the body of a GET/POST .../stream/rules response.
+type v2RulesResponse struct {
+	Data []v2Rule `json:"data"`
+}
+
+// v2JSONDecoder is the default Decoder for bearer/v2 TweetReaders. It
+// decodes a v2 tweet envelope (the tweet plus any author_id/
+// referenced_tweets.id expansions requested) into the package's common
+// Tweet type, so callers can consume either stream version identically.
+type v2JSONDecoder struct{}
+
+// Decode implements Decoder.
+func (v2JSONDecoder) Decode(r io.Reader) (Tweet, error) {
+	var env v2Envelope
+	if err := json.NewDecoder(r).Decode(&env); err != nil {
+		return Tweet{}, err
+	}
+	return env.toTweet(), nil
+}
+
+// v2Envelope is a single line of a v2 filtered stream or search response:
+// the matched tweet plus the objects its expansions resolved to.
+type v2Envelope struct {
+	Data     v2TweetData `json:"data"`
+	Includes v2Includes  `json:"includes"`
+}
+
+// v2Includes holds the expanded objects referenced by a v2TweetData, as
+// requested via the expansions query parameter.
+type v2Includes struct {
+	Users  []v2UserData  `json:"users"`
+	Tweets []v2TweetData `json:"tweets"`
+}
+
+// v2UserData is a v2 user object, as requested via user.fields.
+type v2UserData struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Username string `json:"username"`
+}
+
+// v2TweetData is a v2 tweet object, as requested via tweet.fields.
+type v2TweetData struct {
+	ID               string              `json:"id"`
+	Text             string              `json:"text"`
+	CreatedAt        string              `json:"created_at"`
+	Lang             string              `json:"lang"`
+	AuthorID         string              `json:"author_id"`
+	Entities         v2Entities          `json:"entities"`
+	ReferencedTweets []v2ReferencedTweet `json:"referenced_tweets"`
+	Geo              struct {
+		Coordinates struct {
+			Type        string     `json:"type"`
+			Coordinates [2]float64 `json:"coordinates"`
+		} `json:"coordinates"`
+	} `json:"geo"`
+}
+
+// v2ReferencedTweet points at a retweeted, quoted or replied-to tweet in
+// v2Includes.Tweets.
+type v2ReferencedTweet struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// v2Entities is the v2 shape of hashtags/urls/mentions parsed out of a
+// tweet's text, field names differing from the v1.1 Entities shape.
+type v2Entities struct {
+	Hashtags []struct {
+		Start int    `json:"start"`
+		End   int    `json:"end"`
+		Tag   string `json:"tag"`
+	} `json:"hashtags"`
+	URLs []struct {
+		Start       int    `json:"start"`
+		End         int    `json:"end"`
+		URL         string `json:"url"`
+		ExpandedURL string `json:"expanded_url"`
+		DisplayURL  string `json:"display_url"`
+	} `json:"urls"`
+	Mentions []struct {
+		Start    int    `json:"start"`
+		End      int    `json:"end"`
+		ID       string `json:"id"`
+		Username string `json:"username"`
+	} `json:"mentions"`
+}
+
+// toTweet converts env's matched tweet into the package's common Tweet
+// type, resolving its author and any retweeted/quoted tweet from Includes.
+func (env v2Envelope) toTweet() Tweet {
+	return buildV2Tweet(env.Data, env.Includes)
+}
+
+// buildV2Tweet converts data into a Tweet, resolving User from
+// includes.Users and RetweetedStatus/QuotedStatus from includes.Tweets.
+func buildV2Tweet(data v2TweetData, includes v2Includes) Tweet {
+	var t Tweet
+	t.ID = parseV2ID(data.ID)
+	t.Text = data.Text
+	t.Lang = data.Lang
+	if data.CreatedAt != "" {
+		if ct, err := time.Parse(time.RFC3339, data.CreatedAt); err == nil {
+			t.CreatedAt = ct
+		}
+	}
+	if data.AuthorID != "" {
+		for _, u := range includes.Users {
+			if u.ID != data.AuthorID {
+				continue
+			}
+			t.User = &User{ID: parseV2ID(u.ID), Name: u.Name, ScreenName: u.Username}
+			break
+		}
+	}
+	for _, h := range data.Entities.Hashtags {
+		t.Entities.Hashtags = append(t.Entities.Hashtags, HashtagEntity{Text: h.Tag, Indices: [2]int{h.Start, h.End}})
+	}
+	for _, u := range data.Entities.URLs {
+		t.Entities.URLs = append(t.Entities.URLs, URLEntity{URL: u.URL, ExpandedURL: u.ExpandedURL, DisplayURL: u.DisplayURL, Indices: [2]int{u.Start, u.End}})
+	}
+	for _, m := range data.Entities.Mentions {
+		t.Entities.UserMentions = append(t.Entities.UserMentions, UserMentionEntity{ID: parseV2ID(m.ID), ScreenName: m.Username, Indices: [2]int{m.Start, m.End}})
+	}
+	if data.Geo.Coordinates.Type != "" {
+		t.Coordinates = &Coordinates{Type: data.Geo.Coordinates.Type, Coordinates: data.Geo.Coordinates.Coordinates}
+	}
+	for _, ref := range data.ReferencedTweets {
+		refData, ok := findV2Tweet(includes.Tweets, ref.ID)
+		if !ok {
+			continue
+		}
+		refTweet := buildV2Tweet(refData, includes)
+		switch ref.Type {
+		case "retweeted":
+			t.RetweetedStatus = &refTweet
+		case "quoted":
+			t.QuotedStatus = &refTweet
+		}
+	}
+	return t
+}
+
+func findV2Tweet(tweets []v2TweetData, id string) (v2TweetData, bool) {
+	for _, t := range tweets {
+		if t.ID == id {
+			return t, true
+		}
+	}
+	return v2TweetData{}, false
+}
+
+func parseV2ID(s string) int64 {
+	n, _ := strconv.ParseInt(s, 10, 64)
+	return n
+}
+
+// v2TweetFields, v2Expansions and v2UserFields are the tweet.fields,
+// expansions and user.fields query parameters requested on v2 endpoints,
+// so responses carry enough to populate a full Tweet (by default v2 only
+// returns id and text).
+const (
+	v2TweetFields = "created_at,lang,entities,author_id,geo,referenced_tweets"
+	v2Expansions  = "author_id,referenced_tweets.id"
+	v2UserFields  = "name,username"
+)
+
+// runStreamV2 connects to the v2 filtered stream, reconnecting with
+// r.backoff on errors, and sends decoded tweets into tweetsChan until ctx
+// is done.
+func (r *TweetReader) runStreamV2(ctx context.Context, opts FilterOptions, tweetsChan chan Tweet) {
+	defer close(tweetsChan)
+	if err := r.setStreamRulesV2(ctx, opts); err != nil {
+		log.Println("setting stream rules failed:", err)
+		return
+	}
+	client := &http.Client{}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+		resp, status, err := r.doStreamV2Request(ctx, client, false)
+		if err != nil {
+			log.Println("Error getting response:", err)
+			r.backoff.OnNetErr()
+			if err := r.backoff.Wait(ctx); err != nil {
+				return
+			}
+			continue
+		}
+		if status == http.StatusUnauthorized {
+			resp.Body.Close()
+			resp, status, err = r.doStreamV2Request(ctx, client, true)
+			if err != nil {
+				log.Println("Error getting response:", err)
+				r.backoff.OnNetErr()
+				if err := r.backoff.Wait(ctx); err != nil {
+					return
+				}
+				continue
+			}
+		}
+		if status != http.StatusOK {
+			log.Println("StatusCode =", status)
+			resp.Body.Close()
+			r.backoff.OnHTTPErr(status)
+			if err := r.backoff.Wait(ctx); err != nil {
+				return
+			}
+			continue
+		}
+		var gotMessage bool
+		func() {
+			defer resp.Body.Close()
+			scanner := bufio.NewScanner(resp.Body)
+			scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+			for scanner.Scan() {
+				line := bytes.TrimSpace(scanner.Bytes())
+				if len(line) == 0 {
+					// keep-alive newline
+					continue
+				}
+				t, err := r.decoder.Decode(bytes.NewReader(line))
+				if err != nil {
+					log.Println("decoding tweet failed:", err)
+					continue
+				}
+				gotMessage = true
+				t.Terms = foundTerms(t.Text, opts.Track...)
+				tweetsChan <- t
+			}
+		}()
+		if gotMessage {
+			r.backoff.OnSuccess()
+		} else {
+			r.backoff.OnNetErr()
+			if err := r.backoff.Wait(ctx); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// doStreamV2Request connects to the v2 filtered stream once. If
+// forceRefresh is set, the bearer token is refreshed before connecting,
+// for the single retry after a 401.
+func (r *TweetReader) doStreamV2Request(ctx context.Context, client *http.Client, forceRefresh bool) (*http.Response, int, error) {
+	var token string
+	var err error
+	if forceRefresh {
+		token, err = r.refreshBearer(ctx)
+	} else {
+		token, err = r.bearer(ctx)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	u, _ := url.Parse(v2StreamURL)
+	q := url.Values{}
+	q.Set("tweet.fields", v2TweetFields)
+	q.Set("expansions", v2Expansions)
+	q.Set("user.fields", v2UserFields)
+	u.RawQuery = q.Encode()
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	return resp, resp.StatusCode, nil
+}
+
+// setStreamRulesV2 replaces the account's filtered stream rules with ones
+// derived from opts: opts.Rules if given, otherwise a single rule OR-ing
+// opts.Track together.
+func (r *TweetReader) setStreamRulesV2(ctx context.Context, opts FilterOptions) error {
+	rules := opts.Rules
+	if len(rules) == 0 && len(opts.Track) > 0 {
+		rules = []string{strings.Join(opts.Track, " OR ")}
+	}
+	existing, err := r.getStreamRulesV2(ctx)
+	if err != nil {
+		return err
+	}
+	if len(existing) > 0 {
+		ids := make([]string, len(existing))
+		for i, rule := range existing {
+			ids[i] = rule.ID
+		}
+		if err := r.postStreamRulesV2(ctx, map[string]interface{}{
+			"delete": map[string][]string{"ids": ids},
+		}); err != nil {
+			return err
+		}
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+	add := make([]v2Rule, len(rules))
+	for i, value := range rules {
+		add[i] = v2Rule{Value: value}
+	}
+	return r.postStreamRulesV2(ctx, map[string]interface{}{"add": add})
+}
+
+func (r *TweetReader) getStreamRulesV2(ctx context.Context) ([]v2Rule, error) {
+	token, err := r.bearer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("GET", v2RulesURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("twitterfeed: GET stream/rules returned status %d", resp.StatusCode)
+	}
+	var result v2RulesResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	return result.Data, nil
+}
+
+func (r *TweetReader) postStreamRulesV2(ctx context.Context, body interface{}) error {
+	token, err := r.bearer(ctx)
+	if err != nil {
+		return err
+	}
+	enc, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", v2RulesURL, bytes.NewReader(enc))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("twitterfeed: POST stream/rules returned status %d", resp.StatusCode)
+	}
+	return nil
+}
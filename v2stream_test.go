@@ -0,0 +1,108 @@
+package twitterfeed
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestV2JSONDecoderDecode(t *testing.T) {
+	const payload = `{
+		"data": {
+			"id": "42",
+			"text": "hello",
+			"lang": "en",
+			"created_at": "2017-04-06T15:24:15.000Z",
+			"author_id": "7"
+		},
+		"includes": {
+			"users": [{"id": "7", "name": "Alice", "username": "alice"}]
+		}
+	}`
+	tw, err := v2JSONDecoder{}.Decode(strings.NewReader(payload))
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if tw.ID != 42 || tw.Text != "hello" || tw.Lang != "en" {
+		t.Fatalf("unexpected tweet: %+v", tw)
+	}
+	want := time.Date(2017, time.April, 6, 15, 24, 15, 0, time.UTC)
+	if !tw.CreatedAt.Equal(want) {
+		t.Fatalf("CreatedAt = %v, want %v", tw.CreatedAt, want)
+	}
+	if tw.User == nil || tw.User.ID != 7 || tw.User.ScreenName != "alice" {
+		t.Fatalf("User = %+v, want resolved from includes.users", tw.User)
+	}
+}
+
+func TestBuildV2TweetEntities(t *testing.T) {
+	data := v2TweetData{
+		ID:   "1",
+		Text: "#go is great https://t.co/x cc @bob",
+	}
+	data.Entities.Hashtags = []struct {
+		Start int    `json:"start"`
+		End   int    `json:"end"`
+		Tag   string `json:"tag"`
+	}{{Start: 0, End: 3, Tag: "go"}}
+	data.Entities.URLs = []struct {
+		Start       int    `json:"start"`
+		End         int    `json:"end"`
+		URL         string `json:"url"`
+		ExpandedURL string `json:"expanded_url"`
+		DisplayURL  string `json:"display_url"`
+	}{{Start: 13, End: 27, URL: "https://t.co/x", ExpandedURL: "https://example.com", DisplayURL: "example.com"}}
+	data.Entities.Mentions = []struct {
+		Start    int    `json:"start"`
+		End      int    `json:"end"`
+		ID       string `json:"id"`
+		Username string `json:"username"`
+	}{{Start: 31, End: 35, ID: "99", Username: "bob"}}
+
+	tw := buildV2Tweet(data, v2Includes{})
+	if len(tw.Entities.Hashtags) != 1 || tw.Entities.Hashtags[0].Text != "go" {
+		t.Fatalf("Hashtags = %+v", tw.Entities.Hashtags)
+	}
+	if len(tw.Entities.URLs) != 1 || tw.Entities.URLs[0].ExpandedURL != "https://example.com" {
+		t.Fatalf("URLs = %+v", tw.Entities.URLs)
+	}
+	if len(tw.Entities.UserMentions) != 1 || tw.Entities.UserMentions[0].ID != 99 {
+		t.Fatalf("UserMentions = %+v", tw.Entities.UserMentions)
+	}
+}
+
+func TestBuildV2TweetResolvesRetweetAndQuote(t *testing.T) {
+	includes := v2Includes{
+		Tweets: []v2TweetData{
+			{ID: "10", Text: "original"},
+			{ID: "20", Text: "quoted"},
+		},
+	}
+	data := v2TweetData{
+		ID:   "1",
+		Text: "RT @someone: original",
+		ReferencedTweets: []v2ReferencedTweet{
+			{Type: "retweeted", ID: "10"},
+			{Type: "quoted", ID: "20"},
+		},
+	}
+	tw := buildV2Tweet(data, includes)
+	if tw.RetweetedStatus == nil || tw.RetweetedStatus.Text != "original" {
+		t.Fatalf("RetweetedStatus = %+v", tw.RetweetedStatus)
+	}
+	if tw.QuotedStatus == nil || tw.QuotedStatus.Text != "quoted" {
+		t.Fatalf("QuotedStatus = %+v", tw.QuotedStatus)
+	}
+}
+
+func TestBuildV2TweetUnresolvedReferenceIsIgnored(t *testing.T) {
+	data := v2TweetData{
+		ID:               "1",
+		Text:             "RT @someone: gone",
+		ReferencedTweets: []v2ReferencedTweet{{Type: "retweeted", ID: "missing"}},
+	}
+	tw := buildV2Tweet(data, v2Includes{})
+	if tw.RetweetedStatus != nil {
+		t.Fatalf("RetweetedStatus = %+v, want nil for an unresolved reference", tw.RetweetedStatus)
+	}
+}